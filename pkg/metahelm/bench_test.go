@@ -0,0 +1,50 @@
+package metahelm
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+// fanOutCharts returns n independent leaf charts plus one chart depending
+// on all of them, so a concurrent Install can install the leaves in
+// parallel before the single top-level chart.
+func fanOutCharts(n int) []Chart {
+	deps := make([]string, n)
+	charts := make([]Chart, 0, n+1)
+	for i := 0; i < n; i++ {
+		title := fmt.Sprintf("leaf-%d", i)
+		deps[i] = title
+		charts = append(charts, Chart{
+			Title:                      title,
+			Location:                   "/foo",
+			DeploymentHealthIndication: IgnorePodHealth,
+		})
+	}
+	charts = append(charts, Chart{
+		Title:                      "top",
+		Location:                   "/foo",
+		DeploymentHealthIndication: IgnorePodHealth,
+		DependencyList:             deps,
+	})
+	return charts
+}
+
+func benchmarkInstall(b *testing.B, concurrency int) {
+	charts := fanOutCharts(20)
+	for i := 0; i < b.N; i++ {
+		cfg := fakeHelmConfiguration(b)
+		m := Manager{K8c: fakeKubernetesClientset(b, cfg), HCfg: cfg, Loader: fakeChartLoader}
+		if _, err := m.Install(context.Background(), charts, WithMaxConcurrency(concurrency)); err != nil {
+			b.Fatalf("error installing: %v", err)
+		}
+	}
+}
+
+func BenchmarkInstallSerial(b *testing.B) {
+	benchmarkInstall(b, 1)
+}
+
+func BenchmarkInstallConcurrency8(b *testing.B) {
+	benchmarkInstall(b, 8)
+}
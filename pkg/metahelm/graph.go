@@ -0,0 +1,81 @@
+package metahelm
+
+import "fmt"
+
+// topoOrder returns charts grouped into dependency levels: level 0 contains
+// charts with no unresolved dependencies, level 1 contains charts whose
+// dependencies are all in level 0, and so on. Charts within a level have no
+// dependency relationship to one another and may be installed in parallel.
+//
+// Levels are built by repeatedly scanning charts in reverse so that charts
+// listed after their dependents (the common convention: a top-level chart
+// followed by the infrastructure it depends on) are considered ready first.
+func topoOrder(charts []Chart) ([][]Chart, error) {
+	byTitle := make(map[string]Chart, len(charts))
+	for _, c := range charts {
+		byTitle[c.Title] = c
+	}
+	done := make(map[string]bool, len(charts))
+	remaining := make([]string, len(charts))
+	for i, c := range charts {
+		remaining[i] = c.Title
+	}
+
+	var levels [][]Chart
+	for len(remaining) > 0 {
+		var level []string
+		var next []string
+		for i := len(remaining) - 1; i >= 0; i-- {
+			title := remaining[i]
+			ready := true
+			for _, dep := range byTitle[title].DependencyList {
+				if !done[dep] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				level = append(level, title)
+			} else {
+				next = append([]string{title}, next...)
+			}
+		}
+		if len(level) == 0 {
+			return nil, fmt.Errorf("dependency cycle detected among: %v", remaining)
+		}
+		lc := make([]Chart, len(level))
+		for i, title := range level {
+			lc[i] = byTitle[title]
+			done[title] = true
+		}
+		levels = append(levels, lc)
+		remaining = next
+	}
+	return levels, nil
+}
+
+// reverseTopoOrder returns the same grouping as topoOrder but with the
+// levels reversed, so that charts with no dependents come first. This is
+// the order in which a dependency graph must be torn down: children before
+// their parents.
+func reverseTopoOrder(charts []Chart) ([][]Chart, error) {
+	levels, err := topoOrder(charts)
+	if err != nil {
+		return nil, err
+	}
+	reversed := make([][]Chart, len(levels))
+	for i, l := range levels {
+		reversed[len(levels)-1-i] = l
+	}
+	return reversed, nil
+}
+
+// flatten returns the charts in levels as a single ordered slice, charts in
+// earlier levels first.
+func flatten(levels [][]Chart) []Chart {
+	var out []Chart
+	for _, l := range levels {
+		out = append(out, l...)
+	}
+	return out
+}
@@ -0,0 +1,224 @@
+package metahelm
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// HealthStatus is the observed health of a chart's resources.
+type HealthStatus int
+
+const (
+	// Healthy means every checked resource is ready.
+	Healthy HealthStatus = iota
+	// Degraded means some but not all checked resources are ready.
+	Degraded
+	// Unhealthy means none of the checked resources are ready.
+	Unhealthy
+)
+
+func (s HealthStatus) String() string {
+	switch s {
+	case Healthy:
+		return "healthy"
+	case Degraded:
+		return "degraded"
+	case Unhealthy:
+		return "unhealthy"
+	default:
+		return "unknown"
+	}
+}
+
+// ResourceHealth is the last observed health of a single resource within a
+// chart.
+type ResourceHealth struct {
+	GVK                string
+	Name               string
+	Ready              bool
+	LastTransitionTime time.Time
+}
+
+// ChartHealth summarizes the health of one chart's resources.
+type ChartHealth struct {
+	Status             HealthStatus
+	LastTransitionTime time.Time
+	Resources          []ResourceHealth
+}
+
+// HealthReport is the result of a Healthcheck run, keyed by chart title.
+type HealthReport map[string]ChartHealth
+
+// HealthMetricsSink receives a per-chart health observation each time
+// Healthcheck runs, for exporting as Prometheus-style metrics.
+type HealthMetricsSink interface {
+	ObserveChartHealth(chart string, status HealthStatus)
+}
+
+type healthcheckOptions struct {
+	predicate   func(Chart) bool
+	metricsSink HealthMetricsSink
+}
+
+// HealthcheckOption configures a call to Manager.Healthcheck or
+// Manager.StartHealthMonitor.
+type HealthcheckOption func(*healthcheckOptions)
+
+// WithHealthcheckPredicate restricts Healthcheck to charts for which p
+// returns true, e.g. to skip charts marked with IgnorePodHealth.
+func WithHealthcheckPredicate(p func(Chart) bool) HealthcheckOption {
+	return func(o *healthcheckOptions) { o.predicate = p }
+}
+
+// WithHealthMetricsSink registers a sink that receives a health observation
+// for every chart on every Healthcheck run.
+func WithHealthMetricsSink(sink HealthMetricsSink) HealthcheckOption {
+	return func(o *healthcheckOptions) { o.metricsSink = sink }
+}
+
+// Healthcheck re-runs the readiness checks for every chart in rm that has
+// an entry in charts, reporting a HealthReport keyed by chart title. prev
+// is the HealthReport from the previous call (nil for the first call) and
+// is used to tell whether a resource's Ready state, or a chart's overall
+// Status, actually changed since then: LastTransitionTime only advances on
+// a real transition, not on every poll. It is intended for controllers
+// that want to re-assert cluster state for an already-installed release
+// map without re-deriving a dependency graph.
+func (m Manager) Healthcheck(ctx context.Context, rm ReleaseMap, charts []Chart, prev HealthReport, opts ...HealthcheckOption) (HealthReport, error) {
+	o := &healthcheckOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	report := HealthReport{}
+	for _, c := range charts {
+		if _, ok := rm[c.Title]; !ok {
+			continue
+		}
+		if o.predicate != nil && !o.predicate(c) {
+			continue
+		}
+		if c.DeploymentHealthIndication == IgnorePodHealth && len(c.WaitUntilResources) == 0 {
+			continue
+		}
+		var p *ChartHealth
+		if v, ok := prev[c.Title]; ok {
+			p = &v
+		}
+		ch, err := m.checkChartHealth(ctx, c, p)
+		if err != nil {
+			return report, fmt.Errorf("chart %v: %w", c.Title, err)
+		}
+		report[c.Title] = ch
+		if o.metricsSink != nil {
+			o.metricsSink.ObserveChartHealth(c.Title, ch.Status)
+		}
+	}
+	return report, nil
+}
+
+func (m Manager) checkChartHealth(ctx context.Context, c Chart, prev *ChartHealth) (ChartHealth, error) {
+	prevResources := map[string]ResourceHealth{}
+	if prev != nil {
+		for _, r := range prev.Resources {
+			prevResources[r.GVK+"/"+r.Name] = r
+		}
+	}
+
+	var resources []ResourceHealth
+
+	if c.WaitUntilDeployment != "" && c.DeploymentHealthIndication != IgnorePodHealth {
+		w := ResourceWait{GVK: deploymentGVK, Name: c.WaitUntilDeployment}
+		d, err := m.K8c.AppsV1().Deployments(DefaultK8sNamespace).Get(ctx, c.WaitUntilDeployment, metav1.GetOptions{})
+		ready := err == nil && deploymentHealthy(d, c.DeploymentHealthIndication)
+		resources = append(resources, resourceHealth(w, ready, prevResources))
+	}
+
+	for _, w := range c.WaitUntilResources {
+		checker, err := m.checkerFor(w.GVK)
+		if err != nil {
+			return ChartHealth{}, err
+		}
+		ready, err := checker(ctx, m.K8c, w)
+		if err != nil {
+			return ChartHealth{}, err
+		}
+		resources = append(resources, resourceHealth(w, ready, prevResources))
+	}
+
+	status := summarizeHealth(resources)
+	transition := time.Now()
+	if prev != nil && prev.Status == status {
+		transition = prev.LastTransitionTime
+	}
+	return ChartHealth{Status: status, LastTransitionTime: transition, Resources: resources}, nil
+}
+
+// resourceHealth builds the ResourceHealth observation for w, carrying
+// forward the prior LastTransitionTime from prevResources when w's Ready
+// state is unchanged since the last check.
+func resourceHealth(w ResourceWait, ready bool, prevResources map[string]ResourceHealth) ResourceHealth {
+	gvk := w.GVK.String()
+	transition := time.Now()
+	if p, ok := prevResources[gvk+"/"+w.Name]; ok && p.Ready == ready {
+		transition = p.LastTransitionTime
+	}
+	return ResourceHealth{GVK: gvk, Name: w.Name, Ready: ready, LastTransitionTime: transition}
+}
+
+func summarizeHealth(resources []ResourceHealth) HealthStatus {
+	if len(resources) == 0 {
+		return Healthy
+	}
+	var ready int
+	for _, r := range resources {
+		if r.Ready {
+			ready++
+		}
+	}
+	switch {
+	case ready == len(resources):
+		return Healthy
+	case ready == 0:
+		return Unhealthy
+	default:
+		return Degraded
+	}
+}
+
+// HealthTransitionCallback is invoked by StartHealthMonitor when a chart's
+// health status changes between successive checks.
+type HealthTransitionCallback func(chart string, from, to HealthStatus)
+
+// StartHealthMonitor runs Healthcheck every interval until ctx is done,
+// invoking callback whenever a chart's status transitions (e.g. Healthy ->
+// Degraded). It returns immediately; the monitor runs in a background
+// goroutine.
+func (m Manager) StartHealthMonitor(ctx context.Context, rm ReleaseMap, charts []Chart, interval time.Duration, callback HealthTransitionCallback, opts ...HealthcheckOption) {
+	go func() {
+		var prev HealthReport
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				report, err := m.Healthcheck(ctx, rm, charts, prev, opts...)
+				if err != nil {
+					m.log("health monitor: healthcheck error: %v", err)
+					continue
+				}
+				for title, ch := range report {
+					p, seen := prev[title]
+					if seen && p.Status != ch.Status && callback != nil {
+						callback(title, p.Status, ch.Status)
+					}
+				}
+				prev = report
+			}
+		}
+	}()
+}
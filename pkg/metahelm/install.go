@@ -0,0 +1,420 @@
+package metahelm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"helm.sh/helm/v3/pkg/action"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ChartWaitPollInterval is how frequently a chart's readiness is polled
+// while waiting for it to become healthy. Exposed as a var so tests can
+// speed it up.
+var ChartWaitPollInterval = 5 * time.Second
+
+// retryDelay is how long Install sleeps between invocations of an
+// InstallCallback that returns Wait. Exposed as a var so tests can speed it
+// up.
+var retryDelay = 5 * time.Second
+
+// InstallCallbackAction is returned from an InstallCallback to tell Install
+// how to proceed after a chart's workloads report healthy.
+type InstallCallbackAction int
+
+const (
+	// Continue marks the chart as installed and moves on to charts that
+	// depend on it.
+	Continue InstallCallbackAction = iota
+	// Wait retries the callback after retryDelay without re-running the
+	// chart install.
+	Wait
+	// Abort stops the install run and returns an error.
+	Abort
+)
+
+// InstallCallback is invoked once a chart's workloads have reported
+// healthy, and decides whether the chart is considered done.
+type InstallCallback func(Chart) InstallCallbackAction
+
+// CompletedCallback is invoked once for each chart whose install is
+// attempted, with the error (nil on success) from installing it. Charts
+// that are never started because the run stopped first are not reported.
+type CompletedCallback func(Chart, error)
+
+// ReleaseCreatedCallback is invoked with the cumulative ReleaseMap each time
+// Install creates a new release, so callers can persist in-flight state
+// before a context cancellation triggers a rollback.
+type ReleaseCreatedCallback func(ReleaseMap)
+
+type installOptions struct {
+	installCB        InstallCallback
+	completedCB      CompletedCallback
+	releaseCreated   ReleaseCreatedCallback
+	timeout          time.Duration
+	rollbackOnCancel bool
+	registryPull     RegistryPullOptions
+	maxConcurrency   int
+}
+
+// InstallOption configures a call to Manager.Install.
+type InstallOption func(*installOptions)
+
+// WithInstallCallback sets a callback invoked after each chart reports
+// healthy, to decide whether to proceed, wait, or abort.
+func WithInstallCallback(cb InstallCallback) InstallOption {
+	return func(o *installOptions) { o.installCB = cb }
+}
+
+// WithCompletedCallback sets a callback invoked once for each chart whose
+// install is attempted, after it is installed or fails.
+func WithCompletedCallback(cb CompletedCallback) InstallOption {
+	return func(o *installOptions) { o.completedCB = cb }
+}
+
+// WithTimeout bounds the entire Install run. If d elapses before all charts
+// are installed, Install returns a timeout error.
+func WithTimeout(d time.Duration) InstallOption {
+	return func(o *installOptions) { o.timeout = d }
+}
+
+// WithRollbackOnCancel controls whether Install uninstalls releases already
+// created during a run when ctx is canceled. Defaults to true; pass false
+// to leave partially-applied releases in place for the caller to inspect or
+// resume.
+func WithRollbackOnCancel(rollback bool) InstallOption {
+	return func(o *installOptions) { o.rollbackOnCancel = rollback }
+}
+
+// WithMaxConcurrency allows up to n charts whose dependencies are already
+// satisfied to be installed concurrently. The default, 1, installs charts
+// one at a time in dependency order.
+func WithMaxConcurrency(n int) InstallOption {
+	return func(o *installOptions) {
+		if n < 1 {
+			n = 1
+		}
+		o.maxConcurrency = n
+	}
+}
+
+// WithReleaseCreatedCallback sets a callback invoked with the cumulative
+// ReleaseMap each time Install creates a new release.
+func WithReleaseCreatedCallback(cb ReleaseCreatedCallback) InstallOption {
+	return func(o *installOptions) { o.releaseCreated = cb }
+}
+
+// CancellationError is returned by Install when ctx is canceled before all
+// charts finish installing. It records which of the releases already
+// created were rolled back (uninstalled) versus left in the cluster, per
+// WithRollbackOnCancel.
+type CancellationError struct {
+	RolledBack    []string
+	LeftInstalled []string
+	Err           error
+}
+
+func (e *CancellationError) Error() string {
+	if errors.Is(e.Err, context.DeadlineExceeded) {
+		return fmt.Sprintf("install timeout: %v (rolled back: %v, left installed: %v)", e.Err, e.RolledBack, e.LeftInstalled)
+	}
+	return fmt.Sprintf("install canceled: %v (rolled back: %v, left installed: %v)", e.Err, e.RolledBack, e.LeftInstalled)
+}
+
+// Unwrap returns the error that triggered cancellation, which wraps the
+// context error (context.Canceled or context.DeadlineExceeded) somewhere
+// in its chain.
+func (e *CancellationError) Unwrap() error { return e.Err }
+
+// Install installs charts in dependency order, waiting for each chart to
+// report healthy (per its DeploymentHealthIndication) before installing
+// charts that depend on it. It returns a ReleaseMap of the releases it
+// created.
+//
+// If ctx is canceled before every chart is installed, Install stops
+// scheduling new installs and, unless WithRollbackOnCancel(false) was
+// given, uninstalls every release it had already created, in reverse
+// dependency order, before returning a *CancellationError.
+func (m Manager) Install(ctx context.Context, charts []Chart, opts ...InstallOption) (ReleaseMap, error) {
+	if err := ValidateCharts(charts); err != nil {
+		return nil, fmt.Errorf("invalid charts: %w", err)
+	}
+	o := &installOptions{rollbackOnCancel: true, maxConcurrency: 1}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.timeout)
+		defer cancel()
+	}
+
+	levels, err := topoOrder(charts)
+	if err != nil {
+		return nil, err
+	}
+
+	rm := ReleaseMap{}
+	var mu sync.Mutex
+	for _, level := range levels {
+		if err := ctx.Err(); err != nil {
+			return m.cancelInstall(rm, charts, o, err)
+		}
+		if err := m.installLevel(ctx, level, o, rm, &mu); err != nil {
+			if ctx.Err() != nil {
+				return m.cancelInstall(rm, charts, o, err)
+			}
+			return rm, err
+		}
+	}
+	return rm, nil
+}
+
+// installLevel installs every chart in level, using up to
+// o.maxConcurrency workers. Charts within a level have no dependency
+// relationship to one another, so they may run concurrently; the manager
+// still serializes rm updates and WithInstallCallback/WithCompletedCallback
+// invocations through mu so user callbacks remain race-free. If any chart
+// errors, installLevel cancels its sibling installs and returns that
+// error; with o.maxConcurrency == 1 this reduces to plain sequential
+// installation in level order.
+func (m Manager) installLevel(ctx context.Context, level []Chart, o *installOptions, rm ReleaseMap, mu *sync.Mutex) error {
+	n := o.maxConcurrency
+	if n < 1 {
+		n = 1
+	}
+	if n > len(level) {
+		n = len(level)
+	}
+
+	lctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	work := make(chan Chart)
+	errs := make(chan error, len(level))
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range work {
+				err := m.installOne(lctx, c, o, mu)
+
+				mu.Lock()
+				if err == nil {
+					rm[c.Title] = ReleaseName(c.Title)
+					if o.releaseCreated != nil {
+						o.releaseCreated(copyReleaseMap(rm))
+					}
+				}
+				if o.completedCB != nil {
+					o.completedCB(c, err)
+				}
+				mu.Unlock()
+
+				if err != nil {
+					errs <- fmt.Errorf("chart %v: %w", c.Title, err)
+					cancel()
+					return
+				}
+			}
+		}()
+	}
+
+feed:
+	for _, c := range level {
+		select {
+		case work <- c:
+		case <-lctx.Done():
+			break feed
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	select {
+	case err := <-errs:
+		return err
+	default:
+		return nil
+	}
+}
+
+// cancelInstall unwinds an Install run that was interrupted by ctx
+// cancellation, optionally uninstalling every release created so far.
+func (m Manager) cancelInstall(rm ReleaseMap, charts []Chart, o *installOptions, cause error) (ReleaseMap, error) {
+	if !o.rollbackOnCancel || len(rm) == 0 {
+		left := make([]string, 0, len(rm))
+		for title := range rm {
+			left = append(left, title)
+		}
+		return rm, &CancellationError{LeftInstalled: left, Err: cause}
+	}
+
+	levels, err := reverseTopoOrder(charts)
+	if err != nil {
+		return rm, &CancellationError{Err: fmt.Errorf("%v (additionally, could not determine rollback order: %v)", cause, err)}
+	}
+
+	var rolledBack, leftInstalled []string
+	for _, c := range flatten(levels) {
+		if _, ok := rm[c.Title]; !ok {
+			continue
+		}
+		if err := m.uninstallOne(c.Title); err != nil {
+			m.log("error rolling back chart %v: %v", c.Title, err)
+			leftInstalled = append(leftInstalled, c.Title)
+			continue
+		}
+		rolledBack = append(rolledBack, c.Title)
+		delete(rm, c.Title)
+	}
+	return rm, &CancellationError{RolledBack: rolledBack, LeftInstalled: leftInstalled, Err: cause}
+}
+
+func (m Manager) uninstallOne(title string) error {
+	uninst := action.NewUninstall(m.HCfg)
+	_, err := uninst.Run(ReleaseName(title))
+	return err
+}
+
+func copyReleaseMap(rm ReleaseMap) ReleaseMap {
+	out := make(ReleaseMap, len(rm))
+	for k, v := range rm {
+		out[k] = v
+	}
+	return out
+}
+
+func (m Manager) installOne(ctx context.Context, c Chart, o *installOptions, mu *sync.Mutex) error {
+	m.log("installing chart: %v", c.Title)
+	releaseName := ReleaseName(c.Title)
+
+	inst := action.NewInstall(m.HCfg)
+	inst.ReleaseName = releaseName
+	inst.Namespace = DefaultK8sNamespace
+
+	path, err := m.resolve(ctx, c.Location, o.registryPull)
+	if err != nil {
+		return fmt.Errorf("error resolving chart location: %w", err)
+	}
+	chrt, err := m.loadChart(path)
+	if err != nil {
+		return fmt.Errorf("error loading chart: %w", err)
+	}
+	if _, err := inst.RunWithContext(ctx, chrt, nil); err != nil {
+		return fmt.Errorf("error running install: %w", err)
+	}
+
+	for {
+		if err := m.waitForHealth(ctx, c); err != nil {
+			return err
+		}
+		if err := m.waitForResources(ctx, c); err != nil {
+			return err
+		}
+		if o.installCB == nil {
+			return nil
+		}
+		mu.Lock()
+		cbAction := o.installCB(c)
+		mu.Unlock()
+		switch cbAction {
+		case Continue:
+			return nil
+		case Abort:
+			return fmt.Errorf("install aborted by callback for chart: %v", c.Title)
+		case Wait:
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("timeout waiting on chart %v: %w", c.Title, ctx.Err())
+			case <-time.After(retryDelay):
+			}
+		}
+	}
+}
+
+// waitForHealth polls the chart's Deployment until it satisfies its
+// DeploymentHealthIndication, or ctx expires.
+func (m Manager) waitForHealth(ctx context.Context, c Chart) error {
+	if c.WaitUntilDeployment == "" || c.DeploymentHealthIndication == IgnorePodHealth {
+		return nil
+	}
+	wctx := ctx
+	if c.WaitTimeout > 0 {
+		var cancel context.CancelFunc
+		wctx, cancel = context.WithTimeout(ctx, c.WaitTimeout)
+		defer cancel()
+	}
+	for {
+		d, err := m.K8c.AppsV1().Deployments(DefaultK8sNamespace).Get(wctx, c.WaitUntilDeployment, metav1.GetOptions{})
+		if err == nil && deploymentHealthy(d, c.DeploymentHealthIndication) {
+			return nil
+		}
+		select {
+		case <-wctx.Done():
+			return fmt.Errorf("timeout waiting for deployment %v: %w", c.WaitUntilDeployment, wctx.Err())
+		case <-time.After(ChartWaitPollInterval):
+		}
+	}
+}
+
+// waitForResources polls every entry in c.WaitUntilResources using its
+// registered ReadinessChecker, until all report ready or ctx/WaitTimeout
+// expires.
+func (m Manager) waitForResources(ctx context.Context, c Chart) error {
+	if len(c.WaitUntilResources) == 0 {
+		return nil
+	}
+	wctx := ctx
+	if c.WaitTimeout > 0 {
+		var cancel context.CancelFunc
+		wctx, cancel = context.WithTimeout(ctx, c.WaitTimeout)
+		defer cancel()
+	}
+	pending := append([]ResourceWait{}, c.WaitUntilResources...)
+	for {
+		var stillPending []ResourceWait
+		for _, w := range pending {
+			checker, err := m.checkerFor(w.GVK)
+			if err != nil {
+				return fmt.Errorf("chart %v: %w", c.Title, err)
+			}
+			ready, err := checker(wctx, m.K8c, w)
+			if err != nil {
+				return fmt.Errorf("chart %v: resource %v/%v not ready: %w", c.Title, w.GVK.Kind, w.Name, err)
+			}
+			if !ready {
+				stillPending = append(stillPending, w)
+			}
+		}
+		if len(stillPending) == 0 {
+			return nil
+		}
+		pending = stillPending
+		select {
+		case <-wctx.Done():
+			return fmt.Errorf("timeout waiting for resources on chart %v: %w", c.Title, wctx.Err())
+		case <-time.After(ChartWaitPollInterval):
+		}
+	}
+}
+
+func deploymentHealthy(d *appsv1.Deployment, h DeploymentHealthIndication) bool {
+	switch h {
+	case AtLeastOnePodHealthy:
+		return d.Status.ReadyReplicas > 0
+	case AllPodsHealthy:
+		if d.Spec.Replicas == nil {
+			return d.Status.ReadyReplicas > 0
+		}
+		return d.Status.ReadyReplicas >= *d.Spec.Replicas
+	default:
+		return true
+	}
+}
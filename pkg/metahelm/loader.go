@@ -0,0 +1,20 @@
+package metahelm
+
+import (
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+)
+
+// ChartLoader loads the chart found at a local filesystem path, as
+// returned by Manager.resolve. Manager.Loader may be set to a fake
+// implementation in tests so they don't need real chart archives on disk.
+type ChartLoader func(path string) (*chart.Chart, error)
+
+// loadChart loads the chart at path, using m.Loader if set, and otherwise
+// the default helm loader.Load.
+func (m Manager) loadChart(path string) (*chart.Chart, error) {
+	if m.Loader != nil {
+		return m.Loader(path)
+	}
+	return loader.Load(path)
+}
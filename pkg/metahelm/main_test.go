@@ -0,0 +1,40 @@
+package metahelm
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"testing"
+
+	rls "helm.sh/helm/v3/pkg/release"
+)
+
+var verbose = flag.Bool("verbose", false, "log verbose test output")
+
+// fakeHelmClient is a test double for release lookups made through
+// getRelease (Manager.GetRelease, and so Status and Upgrade's status
+// check), so tests can seed release statuses without a live Kubernetes
+// cluster. Helm actions run against the Configuration's own release storage
+// (install, upgrade, uninstall, rollback) do not see it.
+type fakeHelmClient struct {
+	Rels []*rls.Release
+}
+
+func (f *fakeHelmClient) get(name string) (*rls.Release, error) {
+	for _, r := range f.Rels {
+		if r.Name == name {
+			return r, nil
+		}
+	}
+	return nil, fmt.Errorf("release not found: %v", name)
+}
+
+var fhc = &fakeHelmClient{}
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	getRelease = func(_ Manager, name string) (*rls.Release, error) {
+		return fhc.get(name)
+	}
+	os.Exit(m.Run())
+}
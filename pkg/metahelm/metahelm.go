@@ -0,0 +1,175 @@
+// Package metahelm orchestrates installation and upgrade of a set of Helm
+// charts that have dependencies on one another, expressed as a DAG. It waits
+// for each chart's workloads to become healthy (per a configurable strategy)
+// before proceeding to charts that depend on it.
+package metahelm
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"helm.sh/helm/v3/pkg/action"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DefaultK8sNamespace is the namespace charts are installed and upgraded
+// into, and the namespace used for readiness checks when a ResourceWait
+// does not specify one explicitly.
+const DefaultK8sNamespace = "default"
+
+// Configuration is the Helm action configuration required to perform chart
+// operations (install, upgrade, etc). It is a type alias for
+// action.Configuration so that callers can construct one without importing
+// the Helm action package directly.
+type Configuration = action.Configuration
+
+// DeploymentHealthIndication describes how a chart's readiness should be
+// determined once its Deployment exists.
+type DeploymentHealthIndication int
+
+const (
+	// IgnorePodHealth considers a chart installed as soon as its Helm
+	// install completes, without looking up its Deployment or waiting on
+	// pod readiness.
+	IgnorePodHealth DeploymentHealthIndication = iota
+	// AtLeastOnePodHealthy waits until at least one pod backing the
+	// Deployment is ready.
+	AtLeastOnePodHealthy
+	// AllPodsHealthy waits until every replica of the Deployment is ready.
+	AllPodsHealthy
+)
+
+func (d DeploymentHealthIndication) valid() bool {
+	switch d {
+	case IgnorePodHealth, AtLeastOnePodHealthy, AllPodsHealthy:
+		return true
+	}
+	return false
+}
+
+// Chart describes a single Helm chart to be installed as part of a DAG of
+// charts, along with how to determine when it is ready.
+type Chart struct {
+	Title                      string
+	Location                   string
+	ValuesPath                 string
+	VariableMapping            map[string]string
+	DependencyList             []string
+	WaitUntilDeployment        string
+	WaitTimeout                time.Duration
+	DeploymentHealthIndication DeploymentHealthIndication
+	// WaitUntilResources lists additional resources (StatefulSets,
+	// DaemonSets, Jobs, PVCs, Services, or anything with a registered
+	// ReadinessChecker) that must report ready before charts depending on
+	// this one are installed. WaitUntilDeployment/DeploymentHealthIndication
+	// remain supported for the common Deployment-only case.
+	WaitUntilResources []ResourceWait
+}
+
+// Name returns the Kubernetes/Helm-safe name for this chart, derived from
+// its Title.
+func (c Chart) Name() string {
+	return ReleaseName(c.Title)
+}
+
+// ReleaseMap maps a chart's Title to the name of the Helm release that was
+// created for it.
+type ReleaseMap map[string]string
+
+// Manager installs and upgrades a DAG of Charts.
+type Manager struct {
+	// LogF is used for diagnostic logging. If nil, logging is a no-op.
+	LogF func(format string, v ...interface{})
+	// K8c is the Kubernetes client used to check workload readiness.
+	K8c kubernetes.Interface
+	// HCfg is the Helm action configuration used to perform chart
+	// operations.
+	HCfg *Configuration
+
+	// Resolver, if set, resolves every Chart.Location (plain filesystem
+	// paths as well as oci://, http://, https://, and file:// URLs) to a
+	// local path. If nil, the built-in resolver is used. Tests may substitute a fake to avoid needing a
+	// live registry or HTTP server.
+	Resolver ChartResolver
+
+	// Loader loads the chart found at the filesystem path returned by
+	// resolving Chart.Location. If nil, loader.Load is used. Tests may
+	// substitute a fake to avoid needing real chart archives on disk.
+	Loader ChartLoader
+
+	// readinessCheckers holds per-GVK overrides registered via
+	// RegisterReadinessChecker, layered on top of defaultReadinessCheckers.
+	readinessCheckers map[schema.GroupVersionKind]ReadinessChecker
+}
+
+func (m Manager) log(format string, v ...interface{}) {
+	if m.LogF == nil {
+		return
+	}
+	m.LogF(format, v...)
+}
+
+var releaseNameSanitizer = regexp.MustCompile(`[^a-z0-9-]+`)
+var releaseNameDashes = regexp.MustCompile(`-{2,}`)
+
+// maxReleaseNameLength is the maximum length Helm permits for a release
+// name.
+const maxReleaseNameLength = 53
+
+// ReleaseName sanitizes name into a valid Helm release name: lowercase,
+// alphanumeric-and-dash, no longer than 53 runes.
+func ReleaseName(name string) string {
+	n := strings.ToLower(name)
+	n = releaseNameSanitizer.ReplaceAllString(n, "-")
+	n = releaseNameDashes.ReplaceAllString(n, "-")
+	n = strings.Trim(n, "-")
+	if n == "" {
+		n = "release"
+	}
+	if utf8.RuneCountInString(n) > maxReleaseNameLength {
+		r := []rune(n)
+		n = strings.Trim(string(r[:maxReleaseNameLength]), "-")
+		if n == "" {
+			n = "release"
+		}
+	}
+	return n
+}
+
+// ValidateCharts checks that charts is well-formed: every chart has a title
+// and location, every DependencyList entry refers to a chart present in
+// charts, DeploymentHealthIndication is a known value, and the dependency
+// graph contains no cycles.
+func ValidateCharts(charts []Chart) error {
+	titles := make(map[string]bool, len(charts))
+	for _, c := range charts {
+		if c.Title == "" {
+			return fmt.Errorf("chart title must not be empty")
+		}
+		if c.Location == "" {
+			return fmt.Errorf("chart %v: location must not be empty", c.Title)
+		}
+		if err := validLocationScheme(c.Location); err != nil {
+			return fmt.Errorf("chart %v: %w", c.Title, err)
+		}
+		if !c.DeploymentHealthIndication.valid() {
+			return fmt.Errorf("chart %v: invalid DeploymentHealthIndication: %v", c.Title, c.DeploymentHealthIndication)
+		}
+		titles[c.Title] = true
+	}
+	for _, c := range charts {
+		for _, d := range c.DependencyList {
+			if !titles[d] {
+				return fmt.Errorf("chart %v: unknown dependency: %v", c.Title, d)
+			}
+		}
+	}
+	if _, err := topoOrder(charts); err != nil {
+		return err
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package metahelm
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -16,8 +17,10 @@ import (
 	dockerauth "github.com/deislabs/oras/pkg/auth/docker"
 	"helm.sh/helm/v3/internal/experimental/registry"
 	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chartutil"
 	kubefake "helm.sh/helm/v3/pkg/kube/fake"
+	rls "helm.sh/helm/v3/pkg/release"
 	"helm.sh/helm/v3/pkg/storage"
 	"helm.sh/helm/v3/pkg/storage/driver"
 	appsv1 "k8s.io/api/apps/v1"
@@ -56,6 +59,19 @@ var testCharts = []Chart{
 	},
 }
 
+// fakeChartLoader stands in for loader.Load, returning a minimal valid
+// chart for any path, so Install/Upgrade tests don't need real chart
+// archives on disk.
+func fakeChartLoader(path string) (*chart.Chart, error) {
+	return &chart.Chart{
+		Metadata: &chart.Metadata{
+			Name:       "fake",
+			Version:    "0.1.0",
+			APIVersion: chart.APIVersionV2,
+		},
+	}, nil
+}
+
 func gentestobjs() []runtime.Object {
 	objs := []runtime.Object{}
 	reps := int32(1)
@@ -144,7 +160,7 @@ func actionConfigFixture(t *testing.T) *Configuration {
 	}
 }
 
-func fakeRegistryCache(t *testing.T) *registry.Cache {
+func fakeRegistryCache(t testing.TB) *registry.Cache {
 	t.Helper()
 	tdir, err := ioutil.TempDir("", "helm-action-test")
 	if err != nil {
@@ -161,7 +177,7 @@ func fakeRegistryCache(t *testing.T) *registry.Cache {
 	return cache
 }
 
-func fakeRegistryClient(t *testing.T) *registry.Client {
+func fakeRegistryClient(t testing.TB) *registry.Client {
 	t.Helper()
 	client, err := dockerauth.NewClient()
 	if err != nil {
@@ -187,7 +203,7 @@ func fakeRegistryClient(t *testing.T) *registry.Client {
 	return registryClient
 }
 
-func fakeHelmConfiguration(t *testing.T) *action.Configuration {
+func fakeHelmConfiguration(t testing.TB) *action.Configuration {
 	t.Helper()
 	releases := storage.Init(nil)
 	kubeClient := &kubefake.PrintingKubeClient{Out: ioutil.Discard}
@@ -204,7 +220,7 @@ func fakeHelmConfiguration(t *testing.T) *action.Configuration {
 	}
 }
 
-func fakeKubernetesClientset(t *testing.T, cfg *action.Configuration) kubernetes.Interface {
+func fakeKubernetesClientset(t testing.TB, cfg *action.Configuration) kubernetes.Interface {
 	t.Helper()
 	clientset, err := cfg.KubernetesClientSet()
 	if err != nil {
@@ -218,9 +234,10 @@ func TestGraphInstall(t *testing.T) {
 	fkc := fakeKubernetesClientset(t, cfg)
 	cfg.KubernetesClientSet()
 	m := Manager{
-		LogF: t.Logf,
-		K8c:  fkc,
-		HCfg: cfg,
+		LogF:   t.Logf,
+		K8c:    fkc,
+		HCfg:   cfg,
+		Loader: fakeChartLoader,
 	}
 	ChartWaitPollInterval = 1 * time.Second
 	rm, err := m.Install(context.Background(), testCharts)
@@ -235,9 +252,10 @@ func TestGraphInstallCompletedCallback(t *testing.T) {
 	fkc := fakeKubernetesClientset(t, cfg)
 	cfg.KubernetesClientSet()
 	m := Manager{
-		LogF: t.Logf,
-		K8c:  fkc,
-		HCfg: cfg,
+		LogF:   t.Logf,
+		K8c:    fkc,
+		HCfg:   cfg,
+		Loader: fakeChartLoader,
 	}
 	ChartWaitPollInterval = 1 * time.Second
 	var called int64
@@ -257,9 +275,10 @@ func TestGraphInstallWaitCallback(t *testing.T) {
 	fkc := fakeKubernetesClientset(t, cfg)
 	cfg.KubernetesClientSet()
 	m := Manager{
-		LogF: t.Logf,
-		K8c:  fkc,
-		HCfg: cfg,
+		LogF:   t.Logf,
+		K8c:    fkc,
+		HCfg:   cfg,
+		Loader: fakeChartLoader,
 	}
 	ChartWaitPollInterval = 1 * time.Second
 	var i int
@@ -288,9 +307,10 @@ func TestGraphInstallAbortCallback(t *testing.T) {
 	fkc := fakeKubernetesClientset(t, cfg)
 	cfg.KubernetesClientSet()
 	m := Manager{
-		LogF: t.Logf,
-		K8c:  fkc,
-		HCfg: cfg,
+		LogF:   t.Logf,
+		K8c:    fkc,
+		HCfg:   cfg,
+		Loader: fakeChartLoader,
 	}
 	ChartWaitPollInterval = 1 * time.Second
 	var i int
@@ -316,9 +336,10 @@ func TestGraphInstallTimeout(t *testing.T) {
 	fkc := fakeKubernetesClientset(t, cfg)
 	cfg.KubernetesClientSet()
 	m := Manager{
-		LogF: t.Logf,
-		K8c:  fkc,
-		HCfg: cfg,
+		LogF:   t.Logf,
+		K8c:    fkc,
+		HCfg:   cfg,
+		Loader: fakeChartLoader,
 	}
 	ChartWaitPollInterval = 1 * time.Second
 	cb := func(c Chart) InstallCallbackAction {
@@ -338,6 +359,74 @@ func TestGraphInstallTimeout(t *testing.T) {
 	t.Logf("error: %v", err)
 }
 
+// chainCharts is a linear dependency chain (a -> b -> c) installed one
+// level at a time, so a cancellation triggered while installing "a" is
+// deterministic: the install of "b" or "c" can never have started yet.
+var chainCharts = []Chart{
+	Chart{Title: "a", Location: "/foo", DeploymentHealthIndication: IgnorePodHealth},
+	Chart{Title: "b", Location: "/foo", DeploymentHealthIndication: IgnorePodHealth, DependencyList: []string{"a"}},
+	Chart{Title: "c", Location: "/foo", DeploymentHealthIndication: IgnorePodHealth, DependencyList: []string{"b"}},
+}
+
+func TestGraphInstallCancel(t *testing.T) {
+	cfg := fakeHelmConfiguration(t)
+	fkc := fakeKubernetesClientset(t, cfg)
+	cfg.KubernetesClientSet()
+	m := Manager{
+		LogF:   t.Logf,
+		K8c:    fkc,
+		HCfg:   cfg,
+		Loader: fakeChartLoader,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	rcb := func(rm ReleaseMap) { cancel() }
+	_, err := m.Install(ctx, chainCharts, WithReleaseCreatedCallback(rcb))
+	if err == nil {
+		t.Fatalf("should have returned an error")
+	}
+	var cerr *CancellationError
+	if !errors.As(err, &cerr) {
+		t.Fatalf("expected a *CancellationError, got: %T: %v", err, err)
+	}
+	if len(cerr.RolledBack) != 1 || cerr.RolledBack[0] != "a" {
+		t.Fatalf("unexpected RolledBack: %v", cerr.RolledBack)
+	}
+	if len(cerr.LeftInstalled) != 0 {
+		t.Fatalf("unexpected LeftInstalled: %v", cerr.LeftInstalled)
+	}
+}
+
+func TestGraphInstallCancelNoRollback(t *testing.T) {
+	cfg := fakeHelmConfiguration(t)
+	fkc := fakeKubernetesClientset(t, cfg)
+	cfg.KubernetesClientSet()
+	m := Manager{
+		LogF:   t.Logf,
+		K8c:    fkc,
+		HCfg:   cfg,
+		Loader: fakeChartLoader,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	rcb := func(rm ReleaseMap) { cancel() }
+	rm, err := m.Install(ctx, chainCharts, WithReleaseCreatedCallback(rcb), WithRollbackOnCancel(false))
+	if err == nil {
+		t.Fatalf("should have returned an error")
+	}
+	var cerr *CancellationError
+	if !errors.As(err, &cerr) {
+		t.Fatalf("expected a *CancellationError, got: %T: %v", err, err)
+	}
+	if len(cerr.LeftInstalled) != 1 || cerr.LeftInstalled[0] != "a" {
+		t.Fatalf("unexpected LeftInstalled: %v", cerr.LeftInstalled)
+	}
+	if len(cerr.RolledBack) != 0 {
+		t.Fatalf("unexpected RolledBack: %v", cerr.RolledBack)
+	}
+	if _, ok := rm["a"]; !ok {
+		t.Fatalf("expected release map to still contain chart a: %v", rm)
+	}
+}
+
 func TestValidateCharts(t *testing.T) {
 	charts := []Chart{
 		Chart{
@@ -401,9 +490,10 @@ func TestGraphUpgrade(t *testing.T) {
 	fkc := fakeKubernetesClientset(t, cfg)
 	cfg.KubernetesClientSet()
 	m := Manager{
-		LogF: t.Logf,
-		K8c:  fkc,
-		HCfg: cfg,
+		LogF:   t.Logf,
+		K8c:    fkc,
+		HCfg:   cfg,
+		Loader: fakeChartLoader,
 	}
 	ChartWaitPollInterval = 1 * time.Second
 	um := ReleaseMap{}
@@ -425,9 +515,10 @@ func TestGraphUpgradeMissingRelease(t *testing.T) {
 	fkc := fakeKubernetesClientset(t, cfg)
 	cfg.KubernetesClientSet()
 	m := Manager{
-		LogF: t.Logf,
-		K8c:  fkc,
-		HCfg: cfg,
+		LogF:   t.Logf,
+		K8c:    fkc,
+		HCfg:   cfg,
+		Loader: fakeChartLoader,
 	}
 	ChartWaitPollInterval = 1 * time.Second
 	um := ReleaseMap{}
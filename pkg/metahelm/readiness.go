@@ -0,0 +1,149 @@
+package metahelm
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ResourceWait identifies a single cluster resource whose readiness gates a
+// chart's dependents.
+type ResourceWait struct {
+	GVK       schema.GroupVersionKind
+	Name      string
+	Namespace string
+}
+
+func (w ResourceWait) namespace() string {
+	if w.Namespace == "" {
+		return DefaultK8sNamespace
+	}
+	return w.Namespace
+}
+
+// ReadinessChecker reports whether the resource identified by w is ready.
+// A nil error with a false result means "not ready yet, keep polling"; a
+// non-nil error aborts the wait.
+type ReadinessChecker func(ctx context.Context, k8c kubernetes.Interface, w ResourceWait) (bool, error)
+
+var (
+	deploymentGVK  = appsv1.SchemeGroupVersion.WithKind("Deployment")
+	statefulSetGVK = appsv1.SchemeGroupVersion.WithKind("StatefulSet")
+	daemonSetGVK   = appsv1.SchemeGroupVersion.WithKind("DaemonSet")
+	jobGVK         = batchv1.SchemeGroupVersion.WithKind("Job")
+	pvcGVK         = corev1.SchemeGroupVersion.WithKind("PersistentVolumeClaim")
+	serviceGVK     = corev1.SchemeGroupVersion.WithKind("Service")
+)
+
+// defaultReadinessCheckers holds the built-in ReadinessChecker for each GVK
+// metahelm understands out of the box. Manager.RegisterReadinessChecker
+// overrides or extends this set on a per-Manager basis.
+var defaultReadinessCheckers = map[schema.GroupVersionKind]ReadinessChecker{
+	deploymentGVK:  deploymentReadinessChecker,
+	statefulSetGVK: statefulSetReadinessChecker,
+	daemonSetGVK:   daemonSetReadinessChecker,
+	jobGVK:         jobReadinessChecker,
+	pvcGVK:         pvcReadinessChecker,
+	serviceGVK:     serviceReadinessChecker,
+}
+
+// RegisterReadinessChecker installs a ReadinessChecker for gvk, overriding
+// any built-in or previously registered checker for that GVK. This lets
+// downstream projects add CRD-aware waits without forking metahelm.
+func (m *Manager) RegisterReadinessChecker(gvk schema.GroupVersionKind, fn ReadinessChecker) {
+	if m.readinessCheckers == nil {
+		m.readinessCheckers = map[schema.GroupVersionKind]ReadinessChecker{}
+	}
+	m.readinessCheckers[gvk] = fn
+}
+
+func (m Manager) checkerFor(gvk schema.GroupVersionKind) (ReadinessChecker, error) {
+	if fn, ok := m.readinessCheckers[gvk]; ok {
+		return fn, nil
+	}
+	if fn, ok := defaultReadinessCheckers[gvk]; ok {
+		return fn, nil
+	}
+	return nil, fmt.Errorf("no readiness checker registered for: %v", gvk)
+}
+
+func deploymentReadinessChecker(ctx context.Context, k8c kubernetes.Interface, w ResourceWait) (bool, error) {
+	d, err := k8c.AppsV1().Deployments(w.namespace()).Get(ctx, w.Name, metav1.GetOptions{})
+	if err != nil {
+		return false, nil
+	}
+	if d.Spec.Replicas == nil {
+		return d.Status.ReadyReplicas > 0, nil
+	}
+	return d.Status.ReadyReplicas >= *d.Spec.Replicas, nil
+}
+
+func statefulSetReadinessChecker(ctx context.Context, k8c kubernetes.Interface, w ResourceWait) (bool, error) {
+	s, err := k8c.AppsV1().StatefulSets(w.namespace()).Get(ctx, w.Name, metav1.GetOptions{})
+	if err != nil {
+		return false, nil
+	}
+	var want int32 = 1
+	if s.Spec.Replicas != nil {
+		want = *s.Spec.Replicas
+	}
+	return s.Status.ReadyReplicas >= want && s.Status.UpdatedReplicas == s.Status.Replicas, nil
+}
+
+func daemonSetReadinessChecker(ctx context.Context, k8c kubernetes.Interface, w ResourceWait) (bool, error) {
+	d, err := k8c.AppsV1().DaemonSets(w.namespace()).Get(ctx, w.Name, metav1.GetOptions{})
+	if err != nil {
+		return false, nil
+	}
+	return d.Status.NumberReady == d.Status.DesiredNumberScheduled, nil
+}
+
+func jobReadinessChecker(ctx context.Context, k8c kubernetes.Interface, w ResourceWait) (bool, error) {
+	j, err := k8c.BatchV1().Jobs(w.namespace()).Get(ctx, w.Name, metav1.GetOptions{})
+	if err != nil {
+		return false, nil
+	}
+	for _, cond := range j.Status.Conditions {
+		if cond.Type == batchv1.JobComplete && cond.Status == corev1.ConditionTrue {
+			return true, nil
+		}
+		if cond.Type == batchv1.JobFailed && cond.Status == corev1.ConditionTrue {
+			return false, fmt.Errorf("job failed: %v", w.Name)
+		}
+	}
+	return false, nil
+}
+
+func pvcReadinessChecker(ctx context.Context, k8c kubernetes.Interface, w ResourceWait) (bool, error) {
+	p, err := k8c.CoreV1().PersistentVolumeClaims(w.namespace()).Get(ctx, w.Name, metav1.GetOptions{})
+	if err != nil {
+		return false, nil
+	}
+	return p.Status.Phase == corev1.ClaimBound, nil
+}
+
+func serviceReadinessChecker(ctx context.Context, k8c kubernetes.Interface, w ResourceWait) (bool, error) {
+	svc, err := k8c.CoreV1().Services(w.namespace()).Get(ctx, w.Name, metav1.GetOptions{})
+	if err != nil {
+		return false, nil
+	}
+	if svc.Spec.Type == corev1.ServiceTypeLoadBalancer {
+		return len(svc.Status.LoadBalancer.Ingress) > 0, nil
+	}
+	ep, err := k8c.CoreV1().Endpoints(w.namespace()).Get(ctx, w.Name, metav1.GetOptions{})
+	if err != nil {
+		return false, nil
+	}
+	for _, subset := range ep.Subsets {
+		if len(subset.Addresses) > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,60 @@
+package metahelm
+
+import (
+	"context"
+	"testing"
+)
+
+type fakeResolver struct {
+	path string
+	err  error
+}
+
+func (f *fakeResolver) Resolve(ctx context.Context, location string, opts RegistryPullOptions) (string, error) {
+	return f.path, f.err
+}
+
+func TestManagerResolveUsesResolver(t *testing.T) {
+	m := Manager{Resolver: &fakeResolver{path: "/tmp/some-chart"}}
+	path, err := m.resolve(context.Background(), "oci://registry.example.com/charts/redis:1.2.3", RegistryPullOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if path != "/tmp/some-chart" {
+		t.Fatalf("unexpected path: %v", path)
+	}
+}
+
+func TestManagerResolveBarePath(t *testing.T) {
+	m := Manager{}
+	path, err := m.resolve(context.Background(), "/foo/bar", RegistryPullOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if path != "/foo/bar" {
+		t.Fatalf("unexpected path: %v", path)
+	}
+}
+
+func TestValidLocationScheme(t *testing.T) {
+	cases := []struct {
+		location string
+		wantErr  bool
+	}{
+		{"/foo", false},
+		{"file:///foo", false},
+		{"oci://registry.example.com/charts/redis:1.2.3", false},
+		{"http://example.com/redis.tgz", false},
+		{"https://example.com/redis.tgz", false},
+		{"s3://bucket/redis.tgz", true},
+	}
+	for _, c := range cases {
+		err := validLocationScheme(c.location)
+		if c.wantErr && err == nil {
+			t.Fatalf("%v: expected error", c.location)
+		}
+		if !c.wantErr && err != nil {
+			t.Fatalf("%v: unexpected error: %v", c.location, err)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package metahelm
+
+import (
+	"context"
+	"fmt"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+// Status reports the current Helm status (deployed, failed,
+// pending-upgrade, etc) for every chart's release in rm, keyed by chart
+// title.
+func (m Manager) Status(ctx context.Context, rm ReleaseMap) (map[string]release.Status, error) {
+	out := make(map[string]release.Status, len(rm))
+	for title, releaseName := range rm {
+		if err := ctx.Err(); err != nil {
+			return out, err
+		}
+		r, err := m.GetRelease(releaseName)
+		if err != nil {
+			return out, fmt.Errorf("chart %v: %w", title, err)
+		}
+		if r.Info == nil {
+			continue
+		}
+		out[title] = r.Info.Status
+	}
+	return out, nil
+}
+
+// getRelease is the function Manager.GetRelease delegates to. It is a
+// package-level var, in the same spirit as ChartWaitPollInterval and
+// retryDelay, so tests can substitute a fake release lookup without a live
+// Helm storage backend.
+var getRelease = func(m Manager, name string) (*release.Release, error) {
+	return action.NewGet(m.HCfg).Run(name)
+}
+
+// GetRelease returns the current Helm release object for name.
+func (m Manager) GetRelease(name string) (*release.Release, error) {
+	return getRelease(m, name)
+}
@@ -0,0 +1,73 @@
+package metahelm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	rls "helm.sh/helm/v3/pkg/release"
+)
+
+func TestUpgradeRejectsNonDeployedRelease(t *testing.T) {
+	cfg := fakeHelmConfiguration(t)
+	fkc := fakeKubernetesClientset(t, cfg)
+	cfg.KubernetesClientSet()
+	m := Manager{
+		LogF:   t.Logf,
+		K8c:    fkc,
+		HCfg:   cfg,
+		Loader: fakeChartLoader,
+	}
+	ChartWaitPollInterval = 1 * time.Second
+	rm := ReleaseMap{}
+	rels := []*rls.Release{}
+	for i, c := range testCharts {
+		rn := fmt.Sprintf("release-%v-%v", c.Title, i)
+		rm[c.Title] = rn
+		r := &rls.Release{Name: rn, Info: &rls.Info{Status: rls.StatusDeployed}}
+		if c.Title == "redis" {
+			r.Info.Status = rls.StatusFailed
+		}
+		if err := cfg.Releases.Create(r); err != nil {
+			t.Fatalf("error seeding release %v: %v", rn, err)
+		}
+		rels = append(rels, r)
+	}
+	fhc.Rels = rels
+
+	err := m.Upgrade(context.Background(), rm, testCharts)
+	if err == nil {
+		t.Fatalf("should have failed")
+	}
+	if !strings.Contains(err.Error(), "redis") {
+		t.Fatalf("error should name the offending chart: %v", err)
+	}
+
+	if err := m.Upgrade(context.Background(), rm, testCharts, WithAllowNonDeployedUpgrade(true)); err != nil {
+		t.Fatalf("error upgrading with WithAllowNonDeployedUpgrade: %v", err)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	cfg := fakeHelmConfiguration(t)
+	fkc := fakeKubernetesClientset(t, cfg)
+	cfg.KubernetesClientSet()
+	m := Manager{
+		LogF: t.Logf,
+		K8c:  fkc,
+		HCfg: cfg,
+	}
+	rm := ReleaseMap{"redis": "release-redis-0"}
+	fhc.Rels = []*rls.Release{
+		{Name: "release-redis-0", Info: &rls.Info{Status: rls.StatusDeployed}},
+	}
+	statuses, err := m.Status(context.Background(), rm)
+	if err != nil {
+		t.Fatalf("error getting status: %v", err)
+	}
+	if statuses["redis"] != rls.StatusDeployed {
+		t.Fatalf("unexpected status: %v", statuses["redis"])
+	}
+}
@@ -0,0 +1,158 @@
+package metahelm
+
+import (
+	"context"
+	"fmt"
+
+	"helm.sh/helm/v3/pkg/action"
+)
+
+// UninstallCallback is invoked once per chart as Uninstall (or Rollback)
+// processes it, with the error (if any) from uninstalling (or rolling back)
+// its release.
+type UninstallCallback func(Chart, error)
+
+type uninstallOptions struct {
+	callback        UninstallCallback
+	keepHistory     bool
+	force           bool
+	continueOnError bool
+}
+
+// UninstallOption configures a call to Manager.Uninstall or Manager.Rollback.
+type UninstallOption func(*uninstallOptions)
+
+// WithUninstallCallback sets a callback invoked once per chart after
+// Uninstall (or Rollback) processes it.
+func WithUninstallCallback(cb UninstallCallback) UninstallOption {
+	return func(o *uninstallOptions) { o.callback = cb }
+}
+
+// WithKeepHistory preserves release history instead of purging it on
+// uninstall, mirroring `helm uninstall --keep-history`.
+func WithKeepHistory(keep bool) UninstallOption {
+	return func(o *uninstallOptions) { o.keepHistory = keep }
+}
+
+// WithForce enables Rollback's force behavior, which deletes and recreates
+// resources that Helm cannot patch in place. It has no effect on
+// Uninstall: Helm's uninstall action has no equivalent force semantics, so
+// there is nothing for Uninstall to honor here.
+func WithForce(force bool) UninstallOption {
+	return func(o *uninstallOptions) { o.force = force }
+}
+
+// WithContinueOnError makes Uninstall/Rollback proceed through the rest of
+// the dependency graph after a chart fails to tear down (or has no
+// release in the ReleaseMap), instead of aborting the sweep.
+func WithContinueOnError(continueOnError bool) UninstallOption {
+	return func(o *uninstallOptions) { o.continueOnError = continueOnError }
+}
+
+// Uninstall tears down the Helm releases named in rm for each of charts,
+// walking the dependency graph in reverse so that a chart is uninstalled
+// before the charts it depends on. It returns a ReleaseMap of the releases
+// that were actually removed and an aggregated error, if any.
+func (m Manager) Uninstall(ctx context.Context, rm ReleaseMap, charts []Chart, opts ...UninstallOption) (ReleaseMap, error) {
+	o := &uninstallOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	levels, err := reverseTopoOrder(charts)
+	if err != nil {
+		return nil, err
+	}
+
+	removed := ReleaseMap{}
+	var errs []error
+	for _, c := range flatten(levels) {
+		if err := ctx.Err(); err != nil {
+			return removed, err
+		}
+		releaseName, ok := rm[c.Title]
+		if !ok {
+			if o.continueOnError {
+				continue
+			}
+			return removed, fmt.Errorf("no release found for chart: %v", c.Title)
+		}
+		uninstErr := m.uninstallRelease(releaseName, o)
+		if o.callback != nil {
+			o.callback(c, uninstErr)
+		}
+		if uninstErr != nil {
+			uninstErr = fmt.Errorf("chart %v: %w", c.Title, uninstErr)
+			if !o.continueOnError {
+				return removed, uninstErr
+			}
+			errs = append(errs, uninstErr)
+			continue
+		}
+		removed[c.Title] = releaseName
+	}
+	if len(errs) > 0 {
+		return removed, fmt.Errorf("errors uninstalling %d chart(s): %v", len(errs), errs)
+	}
+	return removed, nil
+}
+
+func (m Manager) uninstallRelease(releaseName string, o *uninstallOptions) error {
+	uninst := action.NewUninstall(m.HCfg)
+	uninst.KeepHistory = o.keepHistory
+	_, err := uninst.Run(releaseName)
+	return err
+}
+
+// Rollback rolls back the Helm releases named in rm for each of charts to
+// revision, walking the dependency graph in reverse so that a chart is
+// rolled back before the charts it depends on.
+func (m Manager) Rollback(ctx context.Context, rm ReleaseMap, charts []Chart, revision int, opts ...UninstallOption) error {
+	o := &uninstallOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	for _, c := range charts {
+		if _, ok := rm[c.Title]; !ok && !o.continueOnError {
+			return fmt.Errorf("no release found for chart: %v", c.Title)
+		}
+	}
+
+	levels, err := reverseTopoOrder(charts)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, c := range flatten(levels) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		releaseName, ok := rm[c.Title]
+		if !ok {
+			continue
+		}
+		rbErr := m.rollbackRelease(releaseName, revision, o)
+		if o.callback != nil {
+			o.callback(c, rbErr)
+		}
+		if rbErr != nil {
+			rbErr = fmt.Errorf("chart %v: %w", c.Title, rbErr)
+			if !o.continueOnError {
+				return rbErr
+			}
+			errs = append(errs, rbErr)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("errors rolling back %d chart(s): %v", len(errs), errs)
+	}
+	return nil
+}
+
+func (m Manager) rollbackRelease(releaseName string, revision int, o *uninstallOptions) error {
+	rb := action.NewRollback(m.HCfg)
+	rb.Version = revision
+	rb.Force = o.force
+	return rb.Run(releaseName)
+}
@@ -0,0 +1,85 @@
+package metahelm
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	rls "helm.sh/helm/v3/pkg/release"
+)
+
+func TestUninstall(t *testing.T) {
+	cfg := fakeHelmConfiguration(t)
+	fkc := fakeKubernetesClientset(t, cfg)
+	cfg.KubernetesClientSet()
+	m := Manager{
+		LogF: t.Logf,
+		K8c:  fkc,
+		HCfg: cfg,
+	}
+	ChartWaitPollInterval = 1 * time.Second
+	rm := ReleaseMap{}
+	rels := []*rls.Release{}
+	for i, c := range testCharts {
+		rn := fmt.Sprintf("release-%v-%v", c.Title, i)
+		rm[c.Title] = rn
+		r := &rls.Release{Name: rn, Version: 1, Info: &rls.Info{Status: rls.StatusDeployed}}
+		if err := cfg.Releases.Create(r); err != nil {
+			t.Fatalf("error seeding release %v: %v", rn, err)
+		}
+		rels = append(rels, r)
+	}
+	fhc.Rels = rels
+
+	var order []string
+	_, err := m.Uninstall(context.Background(), rm, testCharts, WithUninstallCallback(func(c Chart, err error) {
+		order = append(order, c.Title)
+	}))
+	if err != nil {
+		t.Fatalf("error uninstalling: %v", err)
+	}
+	if len(order) != len(testCharts) {
+		t.Fatalf("unexpected uninstall count: %v", len(order))
+	}
+	// toplevel depends on everything else, so it must be torn down first.
+	if order[0] != "toplevel" {
+		t.Fatalf("expected toplevel to be uninstalled first, got: %v", order)
+	}
+}
+
+func TestUninstallMissingReleaseContinueOnError(t *testing.T) {
+	cfg := fakeHelmConfiguration(t)
+	fkc := fakeKubernetesClientset(t, cfg)
+	cfg.KubernetesClientSet()
+	m := Manager{
+		LogF: t.Logf,
+		K8c:  fkc,
+		HCfg: cfg,
+	}
+	ChartWaitPollInterval = 1 * time.Second
+	rm := ReleaseMap{}
+	rels := []*rls.Release{}
+	for i, c := range testCharts {
+		rn := fmt.Sprintf("release-%v-%v", c.Title, i)
+		rm[c.Title] = rn
+		r := &rls.Release{Name: rn, Version: 1, Info: &rls.Info{Status: rls.StatusDeployed}}
+		if err := cfg.Releases.Create(r); err != nil {
+			t.Fatalf("error seeding release %v: %v", rn, err)
+		}
+		rels = append(rels, r)
+	}
+	fhc.Rels = rels
+	delete(rm, testCharts[0].Title)
+
+	if _, err := m.Uninstall(context.Background(), rm, testCharts); err == nil {
+		t.Fatalf("should have failed without WithContinueOnError")
+	}
+	removed, err := m.Uninstall(context.Background(), rm, testCharts, WithContinueOnError(true))
+	if err != nil {
+		t.Fatalf("error uninstalling: %v", err)
+	}
+	if len(removed) != len(testCharts)-1 {
+		t.Fatalf("unexpected removed count: %v", len(removed))
+	}
+}
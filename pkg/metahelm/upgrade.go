@@ -0,0 +1,97 @@
+package metahelm
+
+import (
+	"context"
+	"fmt"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+type upgradeOptions struct {
+	registryPull            RegistryPullOptions
+	allowNonDeployedUpgrade bool
+}
+
+// UpgradeOption configures a call to Manager.Upgrade.
+type UpgradeOption func(*upgradeOptions)
+
+// WithUpgradeRegistryPullOptions sets the options used to pull oci://
+// chart locations during Upgrade.
+func WithUpgradeRegistryPullOptions(o RegistryPullOptions) UpgradeOption {
+	return func(uo *upgradeOptions) { uo.registryPull = o }
+}
+
+// WithAllowNonDeployedUpgrade permits Upgrade to proceed against a release
+// whose current status is not release.StatusDeployed (e.g. a release left
+// behind by a half-failed prior install). By default Upgrade refuses to do
+// this, since upgrading on top of such a release usually compounds the
+// original failure.
+func WithAllowNonDeployedUpgrade(allow bool) UpgradeOption {
+	return func(uo *upgradeOptions) { uo.allowNonDeployedUpgrade = allow }
+}
+
+// Upgrade upgrades the Helm releases named in rm for each of charts. Every
+// chart must have a corresponding entry in rm; Upgrade does not install
+// missing releases. Unless WithAllowNonDeployedUpgrade is given, Upgrade
+// refuses to upgrade a release whose current status is not
+// release.StatusDeployed.
+func (m Manager) Upgrade(ctx context.Context, rm ReleaseMap, charts []Chart, opts ...UpgradeOption) error {
+	if err := ValidateCharts(charts); err != nil {
+		return fmt.Errorf("invalid charts: %w", err)
+	}
+	o := &upgradeOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	for _, c := range charts {
+		releaseName, ok := rm[c.Title]
+		if !ok {
+			return fmt.Errorf("no release found for chart: %v", c.Title)
+		}
+		if o.allowNonDeployedUpgrade {
+			continue
+		}
+		r, err := m.GetRelease(releaseName)
+		if err != nil {
+			return fmt.Errorf("chart %v: error getting current release status: %w", c.Title, err)
+		}
+		if r.Info != nil && r.Info.Status != release.StatusDeployed {
+			return fmt.Errorf("chart %v: refusing to upgrade release %v with status %v", c.Title, releaseName, r.Info.Status)
+		}
+	}
+
+	levels, err := topoOrder(charts)
+	if err != nil {
+		return err
+	}
+	for _, c := range flatten(levels) {
+		if err := m.upgradeOne(ctx, rm[c.Title], c, o); err != nil {
+			return fmt.Errorf("chart %v: %w", c.Title, err)
+		}
+	}
+	return nil
+}
+
+func (m Manager) upgradeOne(ctx context.Context, releaseName string, c Chart, o *upgradeOptions) error {
+	m.log("upgrading chart: %v (release: %v)", c.Title, releaseName)
+
+	up := action.NewUpgrade(m.HCfg)
+	up.Namespace = DefaultK8sNamespace
+
+	path, err := m.resolve(ctx, c.Location, o.registryPull)
+	if err != nil {
+		return fmt.Errorf("error resolving chart location: %w", err)
+	}
+	chrt, err := m.loadChart(path)
+	if err != nil {
+		return fmt.Errorf("error loading chart: %w", err)
+	}
+	if _, err := up.RunWithContext(ctx, releaseName, chrt, nil); err != nil {
+		return fmt.Errorf("error running upgrade: %w", err)
+	}
+	if err := m.waitForHealth(ctx, c); err != nil {
+		return err
+	}
+	return m.waitForResources(ctx, c)
+}